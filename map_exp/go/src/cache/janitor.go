@@ -0,0 +1,50 @@
+package cache
+
+import "time"
+
+// NewWithJanitor creates a ShardedMap identical to NewShardedMap(n) plus
+// a background goroutine that sweeps each shard, under its write lock,
+// every interval, removing entries whose TTL has elapsed. Call Stop to
+// shut the janitor down; the ShardedMap itself remains usable after
+// Stop, it just reverts to lazy expire-on-read.
+func NewWithJanitor(n int, interval time.Duration) *ShardedMap {
+	sm := NewShardedMap(n)
+	sm.stop = make(chan struct{})
+	go sm.runJanitor(interval)
+	return sm
+}
+
+func (sm *ShardedMap) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sm.sweep()
+		case <-sm.stop:
+			return
+		}
+	}
+}
+
+func (sm *ShardedMap) sweep() {
+	now := time.Now()
+	for _, s := range sm.shards {
+		s.mu.Lock()
+		for k, e := range s.data {
+			if e.expired(now) {
+				delete(s.data, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Stop shuts down the background janitor started by NewWithJanitor. It
+// is a no-op on a ShardedMap created without a janitor.
+func (sm *ShardedMap) Stop() {
+	if sm.stop != nil {
+		close(sm.stop)
+	}
+}
@@ -0,0 +1,171 @@
+// Package cache provides map-backed cache implementations used by the
+// mutex_multi benchmark harness.
+package cache
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultShardMultiplier controls the default shard count relative to
+// GOMAXPROCS when a caller doesn't request a specific size.
+const defaultShardMultiplier = 16
+
+// entry is what a shard actually stores: a value plus an optional
+// expiration. expiresAt is the zero time.Time for entries set via Set,
+// which never expire.
+type entry struct {
+	value     int
+	expiresAt time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// shard is one stripe of the keyspace: its own map guarded by its own
+// RWMutex so unrelated keys never contend with each other.
+type shard struct {
+	mu   sync.RWMutex
+	data map[int]entry
+}
+
+// ShardedMap is a concurrent int->int map split across a power-of-two
+// number of shards, each independently locked. Reads and writes to keys
+// in different shards can proceed in parallel.
+type ShardedMap struct {
+	shards []*shard
+	mask   uint64
+	stop   chan struct{}
+}
+
+// NewShardedMap creates a ShardedMap with n shards, rounded up to the
+// next power of two. If n <= 0, it defaults to
+// runtime.GOMAXPROCS(0)*16.
+func NewShardedMap(n int) *ShardedMap {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0) * defaultShardMultiplier
+	}
+	n = nextPowerOfTwo(n)
+
+	sm := &ShardedMap{
+		shards: make([]*shard, n),
+		mask:   uint64(n - 1),
+	}
+	for i := range sm.shards {
+		sm.shards[i] = &shard{data: make(map[int]entry)}
+	}
+	return sm
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// shardFor hashes key with FNV-1a over its byte representation and masks
+// the result down to a shard index.
+func (sm *ShardedMap) shardFor(key int) *shard {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(key))
+	h.Write(buf[:])
+	return sm.shards[h.Sum64()&sm.mask]
+}
+
+// Get returns the value stored for key, if any. An entry whose TTL has
+// elapsed is treated as absent and lazily removed.
+func (sm *ShardedMap) Get(key int) (int, bool) {
+	s := sm.shardFor(key)
+	s.mu.RLock()
+	e, ok := s.data[key]
+	expired := ok && e.expired(time.Now())
+	s.mu.RUnlock()
+
+	if !ok {
+		return 0, false
+	}
+	if expired {
+		s.mu.Lock()
+		if e, ok := s.data[key]; ok && e.expired(time.Now()) {
+			delete(s.data, key)
+		}
+		s.mu.Unlock()
+		return 0, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key with no expiration, replacing any existing
+// entry.
+func (sm *ShardedMap) Set(key, value int) {
+	s := sm.shardFor(key)
+	s.mu.Lock()
+	s.data[key] = entry{value: value}
+	s.mu.Unlock()
+}
+
+// SetWithTTL stores value under key, expiring it after d has elapsed.
+func (sm *ShardedMap) SetWithTTL(key, value int, d time.Duration) {
+	s := sm.shardFor(key)
+	s.mu.Lock()
+	s.data[key] = entry{value: value, expiresAt: time.Now().Add(d)}
+	s.mu.Unlock()
+}
+
+// Delete removes key, if present.
+func (sm *ShardedMap) Delete(key int) {
+	s := sm.shardFor(key)
+	s.mu.Lock()
+	delete(s.data, key)
+	s.mu.Unlock()
+}
+
+// Len returns the total number of non-expired entries across all
+// shards. It is a point-in-time estimate: shards are locked one at a
+// time, not together, and expired entries are counted as absent without
+// being removed.
+func (sm *ShardedMap) Len() int {
+	now := time.Now()
+	total := 0
+	for _, s := range sm.shards {
+		s.mu.RLock()
+		for _, e := range s.data {
+			if !e.expired(now) {
+				total++
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return total
+}
+
+// Range calls f for every non-expired key/value pair, shard by shard.
+// Each shard is snapshotted under its RLock before f is called, so f may
+// itself call back into the ShardedMap without deadlocking. Range stops
+// early if f returns false.
+func (sm *ShardedMap) Range(f func(key, value int) bool) {
+	now := time.Now()
+	for _, s := range sm.shards {
+		s.mu.RLock()
+		snapshot := make(map[int]int, len(s.data))
+		for k, e := range s.data {
+			if !e.expired(now) {
+				snapshot[k] = e.value
+			}
+		}
+		s.mu.RUnlock()
+
+		for k, v := range snapshot {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
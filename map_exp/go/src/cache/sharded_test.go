@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShardedMapSetGetDelete(t *testing.T) {
+	sm := NewShardedMap(4)
+
+	if _, ok := sm.Get(1); ok {
+		t.Fatalf("Get on empty map returned ok=true")
+	}
+
+	sm.Set(1, 100)
+	v, ok := sm.Get(1)
+	if !ok || v != 100 {
+		t.Fatalf("Get(1) = %d, %v; want 100, true", v, ok)
+	}
+
+	sm.Set(1, 200)
+	if v, _ := sm.Get(1); v != 200 {
+		t.Fatalf("Get(1) after overwrite = %d; want 200", v)
+	}
+
+	sm.Delete(1)
+	if _, ok := sm.Get(1); ok {
+		t.Fatalf("Get(1) after Delete returned ok=true")
+	}
+}
+
+func TestShardedMapLenAndRange(t *testing.T) {
+	sm := NewShardedMap(4)
+	want := map[int]int{1: 1, 2: 4, 3: 9, 4: 16}
+	for k, v := range want {
+		sm.Set(k, v)
+	}
+
+	if got := sm.Len(); got != len(want) {
+		t.Fatalf("Len() = %d; want %d", got, len(want))
+	}
+
+	seen := make(map[int]int)
+	sm.Range(func(k, v int) bool {
+		seen[k] = v
+		return true
+	})
+	if len(seen) != len(want) {
+		t.Fatalf("Range visited %d entries; want %d", len(seen), len(want))
+	}
+	for k, v := range want {
+		if seen[k] != v {
+			t.Fatalf("Range saw %d=%d; want %d=%d", k, seen[k], k, v)
+		}
+	}
+}
+
+func TestShardedMapRangeStopsEarly(t *testing.T) {
+	sm := NewShardedMap(4)
+	for i := 0; i < 10; i++ {
+		sm.Set(i, i)
+	}
+
+	count := 0
+	sm.Range(func(k, v int) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Fatalf("Range visited %d entries after returning false; want 1", count)
+	}
+}
+
+func TestShardedMapSetWithTTLExpiresLazily(t *testing.T) {
+	sm := NewShardedMap(4)
+	sm.SetWithTTL(1, 42, 10*time.Millisecond)
+
+	if v, ok := sm.Get(1); !ok || v != 42 {
+		t.Fatalf("Get(1) before expiry = %d, %v; want 42, true", v, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := sm.Get(1); ok {
+		t.Fatalf("Get(1) after expiry returned ok=true")
+	}
+	if got := sm.Len(); got != 0 {
+		t.Fatalf("Len() after expiry = %d; want 0", got)
+	}
+}
+
+func TestShardedMapSetNeverExpires(t *testing.T) {
+	sm := NewShardedMap(4)
+	sm.SetWithTTL(1, 1, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	sm.Set(1, 2)
+
+	if v, ok := sm.Get(1); !ok || v != 2 {
+		t.Fatalf("Get(1) after re-Set = %d, %v; want 2, true", v, ok)
+	}
+}
+
+// rawLen counts entries still physically present in the shards,
+// expired or not, bypassing the logical filtering Len/Get/Range apply.
+// It exists to tell a janitor sweep (which actually deletes) apart from
+// lazy expiry (which removes an entry only when a Get reaches it; Len and
+// Range merely skip it).
+func rawLen(sm *ShardedMap) int {
+	total := 0
+	for _, s := range sm.shards {
+		s.mu.RLock()
+		total += len(s.data)
+		s.mu.RUnlock()
+	}
+	return total
+}
+
+func TestNewWithJanitorSweepsExpiredEntries(t *testing.T) {
+	sm := NewWithJanitor(4, 10*time.Millisecond)
+	defer sm.Stop()
+
+	sm.SetWithTTL(1, 1, 5*time.Millisecond)
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if rawLen(sm) == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("janitor did not physically remove the expired entry within deadline")
+}
+
+func TestStopIsANoOpWithoutJanitor(t *testing.T) {
+	sm := NewShardedMap(4)
+	sm.Stop() // must not panic
+}
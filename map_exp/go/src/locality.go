@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+	"workload"
+)
+
+// localityPayloadWords sizes the per-worker payload carried inside
+// MyData; it exists purely to give each worker's struct a realistic,
+// cache-line-sized footprint to migrate.
+const localityPayloadWords = 16
+
+// MyData is one worker's locality-tracking state: which worker it is,
+// the OS thread it last observed itself running on, and a payload big
+// enough to make migrating the struct between caches actually cost
+// something. It is padded on both sides so neighboring workers' structs
+// never share a cache line.
+type MyData struct {
+	_pad0   [8]uint64
+	id      int
+	tid     int
+	payload []uint64
+	_pad1   [8]uint64
+}
+
+func newMyData(id int) *MyData {
+	return &MyData{
+		id:      id,
+		tid:     getTID(),
+		payload: make([]uint64, localityPayloadWords),
+	}
+}
+
+// moved reports whether d is running on a different OS thread than the
+// last time moved was called for it, updating d.tid as a side effect.
+func moved(d *MyData) uint64 {
+	tid := getTID()
+	if tid != d.tid {
+		d.tid = tid
+		return 1
+	}
+	return 0
+}
+
+// localityTrial drives the same cache workload as trial but additionally
+// tracks, per worker, how often the Go scheduler moves it to a different
+// OS thread. It returns total ops, total migrations, and elapsed time.
+func localityTrial(impl string, numThreads int, threadDuration int, readWrite string, readFrac float64, keyGen workload.KeyGen, seedSource *rand.Rand, ttl time.Duration, janitorInterval time.Duration) (uint64, uint64, time.Duration) {
+	c := newCache(impl, ttl, janitorInterval)
+	if s, ok := c.(stoppable); ok {
+		defer s.Stop()
+	}
+	tc, hasTTL := c.(ttlCache)
+
+	var wg sync.WaitGroup
+	opsCells := make([]counterCell, numThreads)
+	migrationCells := make([]counterCell, numThreads)
+
+	wg.Add(numThreads)
+
+	timeStart := time.Now()
+
+	for i := 0; i < numThreads; i++ {
+		workerRand := rand.New(rand.NewSource(seedSource.Int63()))
+		go func(from int, r *rand.Rand) {
+			defer wg.Done()
+			d := newMyData(from)
+			var numOperations uint64 = 0
+			var numMigrations uint64 = 0
+
+			set := c.Set
+			if ttl > 0 && hasTTL {
+				set = func(key, value int) { tc.SetWithTTL(key, value, ttl) }
+			}
+
+			for time.Now().Before(timeStart.Add(time.Duration(threadDuration) * time.Second)) {
+				for j := 0; j < 10000; j++ {
+					var key = keyGen.Next(r)
+					if readWrite == "rw" {
+						if r.Float64() < readFrac {
+							c.Get(key)
+						} else {
+							set(key, key)
+						}
+					} else if readWrite == "w" {
+						set(key, key)
+					} else {
+						c.Get(key)
+					}
+					numOperations += 1
+					numMigrations += moved(d)
+				}
+			}
+			opsCells[from].n = numOperations
+			migrationCells[from].n = numMigrations
+		}(i, workerRand)
+	}
+	wg.Wait()
+	totalDuration := time.Since(timeStart)
+
+	var opsFinal, migrationsFinal uint64
+	for i := range opsCells {
+		opsFinal += opsCells[i].n
+		migrationsFinal += migrationCells[i].n
+	}
+
+	return opsFinal, migrationsFinal, totalDuration
+}
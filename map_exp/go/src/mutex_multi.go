@@ -1,87 +1,237 @@
 package main
 
 import (
+	"cache"
+	"flag"
+	"fmt"
 	"math/rand"
 	"sync"
-	"fmt"
 	"time"
-	"sync/atomic"
-	"os"
+	"workload"
 )
 
+// newKeyGen builds the workload.KeyGen named by dist: "uniform", "zipf",
+// or "hotcold". keys is the total keyspace size; theta is the Zipfian
+// skew parameter (ignored otherwise). Unknown names fall back to
+// "uniform". keys is clamped to at least 1 so every KeyGen always has a
+// non-empty domain to sample from.
+func newKeyGen(dist string, keys int, theta float64) workload.KeyGen {
+	if keys < 1 {
+		keys = 1
+	}
+	switch dist {
+	case "zipf":
+		return workload.Zipfian(keys, theta)
+	case "hotcold":
+		nHot := keys / 10
+		if nHot < 1 {
+			nHot = 1
+		}
+		return workload.HotCold(nHot, keys-nHot, 0.9)
+	default:
+		return workload.Uniform(keys)
+	}
+}
+
+// kvCache is the common surface the benchmark drives against, letting
+// trial swap implementations without caring which locking strategy is
+// underneath.
+type kvCache interface {
+	Get(key int) (int, bool)
+	Set(key, value int)
+}
+
+// ttlCache is implemented by cache.ShardedMap. Benchmarking TTL expiry
+// overhead only makes sense for implementations that support it, so
+// trial type-asserts into this rather than widening kvCache.
+type ttlCache interface {
+	SetWithTTL(key, value int, d time.Duration)
+}
+
+// stoppable is implemented by caches that run background goroutines
+// (cache.ShardedMap's janitor) which must be shut down after a trial.
+type stoppable interface {
+	Stop()
+}
+
+type mutexMapCache struct {
+	mu   sync.Mutex
+	data map[int]int
+}
+
+func newMutexMapCache() *mutexMapCache {
+	return &mutexMapCache{data: make(map[int]int)}
+}
+
+func (c *mutexMapCache) Get(key int) (int, bool) {
+	c.mu.Lock()
+	v, ok := c.data[key]
+	c.mu.Unlock()
+	return v, ok
+}
+
+func (c *mutexMapCache) Set(key, value int) {
+	c.mu.Lock()
+	c.data[key] = value
+	c.mu.Unlock()
+}
+
+type rwMutexMapCache struct {
+	mu   sync.RWMutex
+	data map[int]int
+}
+
+func newRWMutexMapCache() *rwMutexMapCache {
+	return &rwMutexMapCache{data: make(map[int]int)}
+}
+
+func (c *rwMutexMapCache) Get(key int) (int, bool) {
+	c.mu.RLock()
+	v, ok := c.data[key]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+func (c *rwMutexMapCache) Set(key, value int) {
+	c.mu.Lock()
+	c.data[key] = value
+	c.mu.Unlock()
+}
+
+// newCache builds the cache implementation named by impl: "mutex-map",
+// "rwmutex-map", or "sharded-map". Unknown names fall back to
+// "mutex-map" so old invocations keep working. newCache does not use
+// ttl; trial applies it by writing through SetWithTTL, which only
+// "sharded-map" implements. janitorInterval is only honored by
+// "sharded-map" and controls whether a background janitor sweeps
+// expired entries (0
+// disables it, leaving expiry purely lazy-on-read). This lets callers
+// compare lazy-only expiry against janitor-assisted expiry at a fixed
+// ttl by varying janitorInterval alone.
+func newCache(impl string, ttl time.Duration, janitorInterval time.Duration) kvCache {
+	switch impl {
+	case "rwmutex-map":
+		return newRWMutexMapCache()
+	case "sharded-map":
+		if janitorInterval > 0 {
+			return cache.NewWithJanitor(0, janitorInterval)
+		}
+		return cache.NewShardedMap(0)
+	default:
+		return newMutexMapCache()
+	}
+}
+
+// counterCell holds one goroutine's operation count, padded on both
+// sides to a full cache line so adjacent cells in the slice never share
+// one with another goroutine's cell.
+type counterCell struct {
+	_pad0 [7]uint64
+	n     uint64
+	_pad1 [7]uint64
+}
+
 func main() {
-	fmt.Println("numThreads numTrial totalOps totalDur, opsPerSecond")
+	impl := flag.String("impl", "mutex-map", "cache implementation to benchmark: mutex-map, rwmutex-map, sharded-map")
+	readWrite := flag.String("rw", "rw", "operation mix: r, w, or rw")
+	readFrac := flag.Float64("readFrac", 0.5, "fraction of operations that are reads when -rw=rw (0.0-1.0)")
+	reportPerThread := flag.Bool("reportPerThread", false, "print each goroutine's op count after every trial")
+	locality := flag.Bool("locality", false, "track OS-thread migrations per worker and report migrations/sec")
+	keys := flag.Int("keys", 2, "size of the keyspace to generate accesses over")
+	dist := flag.String("dist", "uniform", "key distribution: uniform, zipf, or hotcold")
+	theta := flag.Float64("theta", 0.99, "Zipfian skew parameter, used when -dist=zipf")
+	ttl := flag.Duration("ttl", 0, "entry TTL for writes; only honored by -impl=sharded-map")
+	janitorInterval := flag.Duration("janitorInterval", 0, "background janitor sweep interval for -impl=sharded-map; 0 disables the janitor (lazy expire-on-read only)")
+	flag.Parse()
+
+	keyGen := newKeyGen(*dist, *keys, *theta)
+	seedSource := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	if *locality {
+		fmt.Println("impl,numThreads,numTrial,readFrac,ttl,janitorInterval,totalOps,totalDur,opsPerSecond,migrationsPerSecond")
+		for numThreads := 1; numThreads < 9; numThreads++ {
+			for trialNumber := 1; trialNumber <= 3; trialNumber++ {
+				ops, migrations, dur := localityTrial(*impl, numThreads, 5, *readWrite, *readFrac, keyGen, seedSource, *ttl, *janitorInterval)
+				fmt.Printf("%s,%d,%d,%f,%s,%s,%d,%s,%f,%f\n", *impl, numThreads, trialNumber, *readFrac, *ttl, *janitorInterval, ops, dur, float64(ops)/dur.Seconds(), float64(migrations)/dur.Seconds())
+			}
+		}
+		return
+	}
+
+	fmt.Println("impl,numThreads,numTrial,readFrac,ttl,janitorInterval,totalOps,totalDur,opsPerSecond")
 	for numThreads := 1; numThreads < 9; numThreads++ {
 		for trialNumber := 1; trialNumber <= 3; trialNumber++ {
-			if len(os.Args) == 2 {
-				val, dur := trial(numThreads, 5, os.Args[1])
-				fmt.Println(numThreads, trialNumber, val, dur, float64(val)/dur.Seconds())
-			} else {
-				fmt.Println("Not proper number of arguments.")
-			}
+			val, dur := trial(*impl, numThreads, 5, *readWrite, *readFrac, *reportPerThread, keyGen, seedSource, *ttl, *janitorInterval)
+			fmt.Printf("%s,%d,%d,%f,%s,%s,%d,%s,%f\n", *impl, numThreads, trialNumber, *readFrac, *ttl, *janitorInterval, val, dur, float64(val)/dur.Seconds())
 		}
 	}
 }
 
-func trial (numThreads int, threadDuration int, readWrite string) (uint64, time.Duration) {
-	var data = make(map[int]int)
-	var mutex = &sync.Mutex{}
-	var wg sync.WaitGroup
-	var ops uint64
+func trial(impl string, numThreads int, threadDuration int, readWrite string, readFrac float64, reportPerThread bool, keyGen workload.KeyGen, seedSource *rand.Rand, ttl time.Duration, janitorInterval time.Duration) (uint64, time.Duration) {
+	c := newCache(impl, ttl, janitorInterval)
+	if s, ok := c.(stoppable); ok {
+		defer s.Stop()
+	}
+	tc, hasTTL := c.(ttlCache)
 
-	rand.Seed(time.Now().UnixNano()) //generate seed
+	var wg sync.WaitGroup
+	cells := make([]counterCell, numThreads)
 
 	wg.Add(numThreads) //reader, writer
 
-
 	timeStart := time.Now()
 
-	for i:=0; i < numThreads; i++ {
-		go func(from int) {
+	for i := 0; i < numThreads; i++ {
+		workerRand := rand.New(rand.NewSource(seedSource.Int63()))
+		go func(from int, r *rand.Rand) {
 			defer wg.Done()
 			var numOperations uint64 = 0
 
-			for time.Now().Before(timeStart.Add((time.Duration(threadDuration) * time.Second))) {
+			set := c.Set
+			if ttl > 0 && hasTTL {
+				set = func(key, value int) { tc.SetWithTTL(key, value, ttl) }
+			}
+
+			for time.Now().Before(timeStart.Add(time.Duration(threadDuration) * time.Second)) {
 				//just some random key/values
 				for i := 0; i < 10000; i++ {
-					var constant = rand.Int()%2 //read or write
+					var key = keyGen.Next(r)
 					if readWrite == "rw" {
-						if constant % 2 == 0 {
-							mutex.Lock()
-							data[constant] = constant
-							mutex.Unlock()
+						if r.Float64() < readFrac {
+							c.Get(key)
 							numOperations += 1
 						} else {
-							mutex.Lock()
-							_ = data[constant]
-							mutex.Unlock()
+							set(key, key)
 							numOperations += 1
 						}
 					} else if readWrite == "w" {
-						mutex.Lock()
-						data[constant] = constant
-						mutex.Unlock()
+						set(key, key)
 						numOperations += 1
 					} else if readWrite == "r" {
-						mutex.Lock()
-						_ = data[constant]
-						mutex.Unlock()
+						c.Get(key)
 						numOperations += 1
 					} else {
 						fmt.Println("Not proper choice.")
 						break
-					}	
+					}
 				}
 			}
-			// fmt.Println("Number of Operations from Writer #", from, ": ", numOperations)
-			atomic.AddUint64(&ops, numOperations)
-		} (i)
+			cells[from].n = numOperations
+		}(i, workerRand)
 	}
 	wg.Wait() //wait for the goroutines to finish
 	totalDuration := time.Since(timeStart)
-	opsFinal := atomic.LoadUint64(&ops)
-	
-	// fmt.Println(opsFinal)
 
-    return opsFinal, totalDuration
-}
\ No newline at end of file
+	var opsFinal uint64
+	for i := range cells {
+		opsFinal += cells[i].n
+	}
+
+	if reportPerThread {
+		for i := range cells {
+			fmt.Printf("  thread %d: %d ops\n", i, cells[i].n)
+		}
+	}
+
+	return opsFinal, totalDuration
+}
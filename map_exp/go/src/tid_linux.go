@@ -0,0 +1,12 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// getTID returns the kernel thread id the calling goroutine is currently
+// running on, so repeated calls from the same goroutine reveal when the
+// Go scheduler has migrated it to a different OS thread.
+func getTID() int {
+	return syscall.Gettid()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import "runtime"
+
+// getTID is the non-Linux fallback. There is no portable, cgo-free way
+// to read the OS thread id here. It returns the process-wide number of
+// live goroutines modulo GOMAXPROCS; it does not identify the OS thread
+// or P the caller runs on, so its value changes when goroutines start or
+// exit, not when the scheduler moves the calling goroutine.
+func getTID() int {
+	return runtime.NumGoroutine() % runtime.GOMAXPROCS(0)
+}
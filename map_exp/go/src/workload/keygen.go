@@ -0,0 +1,121 @@
+// Package workload generates the key access patterns the benchmark
+// harness drives against a cache: uniform, Zipfian (YCSB-style hot
+// skew), and a simple hot/cold split.
+package workload
+
+import (
+	"math"
+	"math/rand"
+)
+
+// KeyGen produces the next key to access, in [0, n) for whatever n the
+// generator was built with. Callers supply their own *rand.Rand so each
+// worker goroutine can draw from an unshared source.
+type KeyGen interface {
+	Next(r *rand.Rand) int
+}
+
+// Uniform returns a KeyGen that picks uniformly among keys [0, n). n <=
+// 0 is treated as a domain of exactly one key (key 0) rather than
+// panicking.
+func Uniform(n int) KeyGen {
+	return uniform{n: n}
+}
+
+type uniform struct {
+	n int
+}
+
+func (u uniform) Next(r *rand.Rand) int {
+	if u.n <= 0 {
+		return 0
+	}
+	return r.Intn(u.n)
+}
+
+// Zipfian returns a KeyGen that skews toward low key numbers following a
+// Zipfian distribution with the given theta (0 < theta < 1; higher
+// values skew harder toward key 0). It uses the rejection-free
+// transform from Gray & Sanders, "Quickly Generating Billion-Record
+// Synthetic Databases" (as used by YCSB's ZipfianGenerator), with
+// zetan and eta precomputed once at construction time.
+func Zipfian(n int, theta float64) KeyGen {
+	if theta <= 0 {
+		theta = 0.99
+	}
+	zetan := zeta(n, theta)
+	zeta2 := zeta(2, theta)
+	alpha := 1.0 / (1.0 - theta)
+	eta := (1 - math.Pow(2.0/float64(n), 1-theta)) / (1 - zeta2/zetan)
+
+	return &zipfian{
+		n:     n,
+		theta: theta,
+		alpha: alpha,
+		zetan: zetan,
+		eta:   eta,
+	}
+}
+
+func zeta(n int, theta float64) float64 {
+	var sum float64
+	for i := 1; i <= n; i++ {
+		sum += 1.0 / math.Pow(float64(i), theta)
+	}
+	return sum
+}
+
+type zipfian struct {
+	n     int
+	theta float64
+	alpha float64
+	zetan float64
+	eta   float64
+}
+
+func (z *zipfian) Next(r *rand.Rand) int {
+	u := r.Float64()
+	uz := u * z.zetan
+	if uz < 1.0 {
+		return 0
+	}
+	if uz < 1.0+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	key := int(float64(z.n) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if key >= z.n {
+		key = z.n - 1
+	}
+	return key
+}
+
+// HotCold returns a KeyGen over nHot+nCold keys where hotFrac of
+// accesses land uniformly among the first nHot keys and the rest land
+// uniformly among the remaining nCold keys.
+func HotCold(nHot, nCold int, hotFrac float64) KeyGen {
+	return hotCold{nHot: nHot, nCold: nCold, hotFrac: hotFrac}
+}
+
+type hotCold struct {
+	nHot, nCold int
+	hotFrac     float64
+}
+
+func (h hotCold) Next(r *rand.Rand) int {
+	// A zero-sized side can't be sampled from; fall back to whichever
+	// side actually has keys rather than panicking on Intn(0). If both
+	// are empty, there's no domain at all, so just return 0.
+	if h.nHot == 0 && h.nCold == 0 {
+		return 0
+	}
+	if h.nCold == 0 {
+		return r.Intn(h.nHot)
+	}
+	if h.nHot == 0 {
+		return r.Intn(h.nCold)
+	}
+	if r.Float64() < h.hotFrac {
+		return r.Intn(h.nHot)
+	}
+	return h.nHot + r.Intn(h.nCold)
+}
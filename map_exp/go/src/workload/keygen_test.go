@@ -0,0 +1,89 @@
+package workload
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestUniformBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := Uniform(10)
+	for i := 0; i < 1000; i++ {
+		k := kg.Next(r)
+		if k < 0 || k >= 10 {
+			t.Fatalf("Uniform(10).Next() = %d; want [0, 10)", k)
+		}
+	}
+}
+
+func TestZipfianBoundsAndSkew(t *testing.T) {
+	const n = 100
+	r := rand.New(rand.NewSource(1))
+	kg := Zipfian(n, 0.99)
+
+	counts := make([]int, n)
+	const samples = 20000
+	for i := 0; i < samples; i++ {
+		k := kg.Next(r)
+		if k < 0 || k >= n {
+			t.Fatalf("Zipfian(%d).Next() = %d; want [0, %d)", n, k, n)
+		}
+		counts[k]++
+	}
+
+	// theta=0.99 is a strong skew: key 0 should dominate the
+	// distribution, well above the 1/n uniform share.
+	if counts[0] < samples/10 {
+		t.Fatalf("key 0 got %d/%d samples; want at least 1/10 of samples under theta=0.99", counts[0], samples)
+	}
+	if counts[0] <= counts[n-1] {
+		t.Fatalf("counts[0]=%d should be greater than counts[n-1]=%d under Zipfian skew", counts[0], counts[n-1])
+	}
+}
+
+func TestHotColdBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := HotCold(2, 8, 0.9)
+	for i := 0; i < 1000; i++ {
+		k := kg.Next(r)
+		if k < 0 || k >= 10 {
+			t.Fatalf("HotCold(2, 8, 0.9).Next() = %d; want [0, 10)", k)
+		}
+	}
+}
+
+func TestHotColdEmptyColdFallsBackToHot(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := HotCold(3, 0, 0.9)
+	for i := 0; i < 100; i++ {
+		if k := kg.Next(r); k < 0 || k >= 3 {
+			t.Fatalf("HotCold(3, 0, 0.9).Next() = %d; want [0, 3)", k)
+		}
+	}
+}
+
+func TestHotColdEmptyHotFallsBackToCold(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := HotCold(0, 3, 0.9)
+	for i := 0; i < 100; i++ {
+		if k := kg.Next(r); k < 0 || k >= 3 {
+			t.Fatalf("HotCold(0, 3, 0.9).Next() = %d; want [0, 3)", k)
+		}
+	}
+}
+
+func TestHotColdBothEmptyDoesNotPanic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := HotCold(0, 0, 0.9)
+	if k := kg.Next(r); k != 0 {
+		t.Fatalf("HotCold(0, 0, 0.9).Next() = %d; want 0", k)
+	}
+}
+
+func TestUniformZeroDoesNotPanic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kg := Uniform(0)
+	if k := kg.Next(r); k != 0 {
+		t.Fatalf("Uniform(0).Next() = %d; want 0", k)
+	}
+}